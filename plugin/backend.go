@@ -0,0 +1,109 @@
+package jwtsecrets
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+type backend struct {
+	*framework.Backend
+
+	configLock sync.RWMutex
+	config     *config
+
+	// keyLock guards rotation/persistence of the key ring and the cache
+	// below, see keyring.go.
+	keyLock     sync.RWMutex
+	ringCache   *keyRing
+	ringCacheAt time.Time
+
+	// tidyCASGuard keeps periodicFunc from starting a tidy sweep of the
+	// revocation store while a previous one is still running, the same guard
+	// AppRole uses for secret ID tidy. The on-demand tidy path does not take it.
+	tidyCASGuard uint32
+	lastTidy     time.Time
+}
+
+// Factory configures and returns jwt secrets backends
+func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+	b, err := makeBackend()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.Setup(ctx, conf); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func makeBackend() (*backend, error) {
+	b := &backend{
+		config: defaultConfig(),
+	}
+
+	b.Backend = &framework.Backend{
+		Help: strings.TrimSpace(backendHelp),
+		Paths: []*framework.Path{
+			pathConfig(b),
+			pathRole(b),
+			pathRoleList(b),
+			pathSign(b),
+			pathOIDCDiscovery(b),
+			pathJWKS(b),
+			pathRevoke(b),
+			pathRevokedList(b),
+			pathVerify(b),
+			pathTidy(b),
+		},
+		PathsSpecial: &logical.Paths{
+			Unauthenticated: []string{
+				`\.well-known/openid-configuration`,
+				`\.well-known/jwks\.json`,
+			},
+		},
+		PeriodicFunc: b.periodicFunc,
+		BackendType:  logical.TypeLogical,
+	}
+
+	return b, nil
+}
+
+// periodicFunc runs on Vault's periodic tick and tidies the revocation store
+// when enable_revocation is on and revocation_tidy_interval has elapsed
+// since the last sweep.
+func (b *backend) periodicFunc(ctx context.Context, req *logical.Request) error {
+	b.configLock.RLock()
+	enabled := b.config.EnableRevocation
+	interval := b.config.RevocationTidyInterval
+	b.configLock.RUnlock()
+
+	if !enabled {
+		return nil
+	}
+
+	if !atomic.CompareAndSwapUint32(&b.tidyCASGuard, 0, 1) {
+		return nil
+	}
+	defer atomic.StoreUint32(&b.tidyCASGuard, 0)
+
+	if time.Since(b.lastTidy) < interval {
+		return nil
+	}
+	b.lastTidy = time.Now()
+
+	_, err := b.tidyRevokedEntries(ctx, req.Storage)
+	return err
+}
+
+const backendHelp = `
+The JWT secrets backend signs JSON Web Tokens using keys that it manages
+and rotates on a schedule. Claim policy is configured per named role.
+`
@@ -0,0 +1,52 @@
+package jwtsecrets
+
+import "time"
+
+// config holds the key-management defaults for the backend. Claim policy
+// (issuer, audience/subject patterns, allowed claims, token TTL, ...) lives
+// on individual roles instead, see path_role.go.
+type config struct {
+	KeyRotationPeriod time.Duration
+
+	// IssuerURLOverride is the externally-reachable URL operators advertise
+	// in the OIDC discovery document, for when it differs from Vault's own
+	// mount path (e.g. behind a load balancer or API gateway).
+	IssuerURLOverride string
+
+	// SigningAlgorithm is the algorithm used for the backend's signing key.
+	// Roles may pin themselves to this algorithm, see role.SigningAlgorithm.
+	SigningAlgorithm signingAlgorithm
+
+	// EnableRevocation makes the verify path reject tokens whose 'jti' has
+	// been revoked, and turns on the periodic tidy of expired revocation
+	// entries. The revoke/<jti>, revoked and tidy paths work either way.
+	EnableRevocation bool
+
+	// RevocationTidyInterval is the minimum time between automatic tidy
+	// sweeps of the revocation store.
+	RevocationTidyInterval time.Duration
+
+	// KeyOverlapCount is how many retired keys stay published in JWKS
+	// alongside the current signing key.
+	KeyOverlapCount int
+
+	// ClockSkewLeeway accounts for clock drift between this backend and
+	// verifiers: it extends a retired key's publication window, and is
+	// given to /verify as leeway on a token's exp/nbf claims.
+	ClockSkewLeeway time.Duration
+
+	// PrepublishDuration is how long before a key becomes the signer that
+	// it's already published in JWKS, so verifier caches warm up.
+	PrepublishDuration time.Duration
+}
+
+func defaultConfig() *config {
+	return &config{
+		KeyRotationPeriod:      24 * time.Hour,
+		SigningAlgorithm:       algRS256,
+		RevocationTidyInterval: 1 * time.Hour,
+		KeyOverlapCount:        1,
+		ClockSkewLeeway:        5 * time.Minute,
+		PrepublishDuration:     1 * time.Hour,
+	}
+}
@@ -0,0 +1,149 @@
+package jwtsecrets
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+type signingAlgorithm string
+
+const (
+	algRS256 signingAlgorithm = "RS256"
+	algRS384 signingAlgorithm = "RS384"
+	algRS512 signingAlgorithm = "RS512"
+	algPS256 signingAlgorithm = "PS256"
+	algES256 signingAlgorithm = "ES256"
+	algES384 signingAlgorithm = "ES384"
+	algES512 signingAlgorithm = "ES512"
+	algEdDSA signingAlgorithm = "EdDSA"
+)
+
+func validSigningAlgorithm(alg string) bool {
+	switch signingAlgorithm(alg) {
+	case algRS256, algRS384, algRS512, algPS256, algES256, algES384, algES512, algEdDSA:
+		return true
+	}
+	return false
+}
+
+func supportedSigningAlgorithms() []string {
+	return []string{
+		string(algRS256), string(algRS384), string(algRS512), string(algPS256),
+		string(algES256), string(algES384), string(algES512), string(algEdDSA),
+	}
+}
+
+// key is a single signing/verification keypair tracked by the backend. The
+// concrete type behind PrivateKey is determined by Algorithm: *rsa.PrivateKey
+// for the RS*/PS* families, *ecdsa.PrivateKey for ES*, ed25519.PrivateKey for
+// EdDSA. NotBefore is when this key starts signing (or, for a key that's
+// still pending, will start signing); see keyring.go for how the backend
+// keeps several of these around at once and derives when a retired key's
+// publication window ends.
+type key struct {
+	ID            string           `json:"id"`
+	Algorithm     signingAlgorithm `json:"algorithm"`
+	PrivateKeyDER []byte           `json:"private_key_der"`
+	PrivateKey    crypto.Signer    `json:"-"`
+	CreatedAt     time.Time        `json:"created_at"`
+	NotBefore     time.Time        `json:"not_before"`
+}
+
+func generateKey(alg signingAlgorithm) (*key, error) {
+	var signer crypto.Signer
+	var err error
+
+	switch alg {
+	case algRS256, algPS256:
+		signer, err = rsa.GenerateKey(rand.Reader, 2048)
+	case algRS384:
+		signer, err = rsa.GenerateKey(rand.Reader, 3072)
+	case algRS512:
+		signer, err = rsa.GenerateKey(rand.Reader, 4096)
+	case algES256:
+		signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case algES384:
+		signer, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case algES512:
+		signer, err = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	case algEdDSA:
+		_, priv, genErr := ed25519.GenerateKey(rand.Reader)
+		signer, err = priv, genErr
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(signer)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := generateUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	return &key{
+		ID:            id,
+		Algorithm:     alg,
+		PrivateKeyDER: der,
+		PrivateKey:    signer,
+		CreatedAt:     time.Now(),
+	}, nil
+}
+
+// hydrate reconstructs PrivateKey from PrivateKeyDER after a key has been
+// round-tripped through storage.
+func (k *key) hydrate() error {
+	signer, err := x509.ParsePKCS8PrivateKey(k.PrivateKeyDER)
+	if err != nil {
+		return err
+	}
+	cryptoSigner, ok := signer.(crypto.Signer)
+	if !ok {
+		return fmt.Errorf("stored key %q is not a signing key", k.ID)
+	}
+	k.PrivateKey = cryptoSigner
+	return nil
+}
+
+func jwkForKey(k *key) (map[string]interface{}, error) {
+	base := map[string]interface{}{
+		"kid": k.ID,
+		"use": "sig",
+		"alg": string(k.Algorithm),
+	}
+
+	switch pub := k.PrivateKey.Public().(type) {
+	case *rsa.PublicKey:
+		base["kty"] = "RSA"
+		base["n"] = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		base["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		base["kty"] = "EC"
+		base["crv"] = pub.Curve.Params().Name
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		base["x"] = base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size)))
+		base["y"] = base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		base["kty"] = "OKP"
+		base["crv"] = "Ed25519"
+		base["x"] = base64.RawURLEncoding.EncodeToString(pub)
+	default:
+		return nil, fmt.Errorf("unsupported public key type for key %q", k.ID)
+	}
+
+	return base, nil
+}
@@ -0,0 +1,94 @@
+package jwtsecrets
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"testing"
+)
+
+func TestGenerateKey_PerAlgorithmKeyMaterial(t *testing.T) {
+	cases := []struct {
+		alg       signingAlgorithm
+		rsaBits   int
+		ecBits    int
+		wantEd255 bool
+	}{
+		{alg: algRS256, rsaBits: 2048},
+		{alg: algRS384, rsaBits: 3072},
+		{alg: algRS512, rsaBits: 4096},
+		{alg: algPS256, rsaBits: 2048},
+		{alg: algES256, ecBits: 256},
+		{alg: algES384, ecBits: 384},
+		{alg: algES512, ecBits: 521},
+		{alg: algEdDSA, wantEd255: true},
+	}
+
+	for _, c := range cases {
+		t.Run(string(c.alg), func(t *testing.T) {
+			k, err := generateKey(c.alg)
+			if err != nil {
+				t.Fatalf("generateKey(%s): %v", c.alg, err)
+			}
+			if k.Algorithm != c.alg {
+				t.Fatalf("expected Algorithm %s, got %s", c.alg, k.Algorithm)
+			}
+
+			switch pub := k.PrivateKey.Public().(type) {
+			case *rsa.PublicKey:
+				if c.rsaBits == 0 {
+					t.Fatalf("%s produced an RSA key, expected something else", c.alg)
+				}
+				if got := pub.N.BitLen(); got != c.rsaBits {
+					t.Fatalf("%s: expected %d-bit RSA key, got %d bits", c.alg, c.rsaBits, got)
+				}
+			case *ecdsa.PublicKey:
+				if c.ecBits == 0 {
+					t.Fatalf("%s produced an EC key, expected something else", c.alg)
+				}
+				if got := pub.Curve.Params().BitSize; got != c.ecBits {
+					t.Fatalf("%s: expected a %d-bit curve, got %d", c.alg, c.ecBits, got)
+				}
+			case ed25519.PublicKey:
+				if !c.wantEd255 {
+					t.Fatalf("%s produced an Ed25519 key, expected something else", c.alg)
+				}
+			default:
+				t.Fatalf("%s: unexpected public key type %T", c.alg, pub)
+			}
+
+			jwk, err := jwkForKey(k)
+			if err != nil {
+				t.Fatalf("jwkForKey(%s): %v", c.alg, err)
+			}
+			if jwk["alg"] != string(c.alg) {
+				t.Fatalf("jwk alg mismatch: %#v", jwk)
+			}
+			if jwk["kid"] != k.ID {
+				t.Fatalf("jwk kid mismatch: %#v", jwk)
+			}
+		})
+	}
+}
+
+func TestGenerateKey_UnsupportedAlgorithm(t *testing.T) {
+	if _, err := generateKey("none"); err == nil {
+		t.Fatal("expected an error for an unsupported algorithm")
+	}
+}
+
+func TestKeyHydrate_RoundTrip(t *testing.T) {
+	k, err := generateKey(algES256)
+	if err != nil {
+		t.Fatalf("generateKey: %v", err)
+	}
+
+	der := k.PrivateKeyDER
+	hydrated := &key{ID: k.ID, Algorithm: k.Algorithm, PrivateKeyDER: der}
+	if err := hydrated.hydrate(); err != nil {
+		t.Fatalf("hydrate: %v", err)
+	}
+	if hydrated.PrivateKey == nil {
+		t.Fatal("expected PrivateKey to be populated after hydrate")
+	}
+}
@@ -0,0 +1,400 @@
+package jwtsecrets
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// keyState is the lifecycle stage of a key within the ring: pending keys are
+// published in JWKS ahead of time so caches warm up, exactly one key is
+// signing new tokens, and retired keys stay published only long enough for
+// tokens they signed to finish expiring.
+type keyState string
+
+const (
+	keyStatePending keyState = "pending"
+	keyStateSigning keyState = "signing"
+	keyStateRetired keyState = "retired"
+)
+
+const keyRingStorageKey = "key/ring"
+
+// legacyCurrentKeyStorageKey is where earlier versions of this backend kept
+// their single signing key. loadRing migrates it into the ring the first
+// time it runs against such a store, so upgrading doesn't orphan whatever
+// key already-issued tokens were signed with.
+const legacyCurrentKeyStorageKey = "key/current"
+
+// ringCacheTTL bounds how often rotateRing re-derives the ring from storage.
+// currentKey and jwks are reachable from the verify/sign paths and, for
+// jwks, the unauthenticated .well-known endpoint, so without a cache every
+// request would pay a role list/get per role just to check whether a
+// rotation is due.
+const ringCacheTTL = 10 * time.Second
+
+// keyRing is the set of keys the backend currently knows about, ordered
+// oldest to newest by NotBefore.
+type keyRing struct {
+	Keys []*key `json:"keys"`
+}
+
+// currentKey returns the key that is currently signing new tokens, rotating
+// the ring first if it's due.
+func (b *backend) currentKey(ctx context.Context, s logical.Storage) (*key, error) {
+	ring, err := b.rotateRing(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	states := ringStates(ring.Keys, now)
+	for i := len(ring.Keys) - 1; i >= 0; i-- {
+		if states[i] == keyStateSigning {
+			return ring.Keys[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("no signing key available")
+}
+
+// verificationKeys returns every key whose public half should still be
+// published for verification: the signing key, any prepublished pending
+// key, and retired keys still inside their overlap window.
+func (b *backend) verificationKeys(ctx context.Context, s logical.Storage) ([]*key, error) {
+	ring, err := b.rotateRing(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	return ring.Keys, nil
+}
+
+// ringStates labels each key in ring order. Exactly one key is "signing":
+// the newest one whose NotBefore has arrived. Everything older than it that
+// has also arrived is "retired"; everything still in the future is
+// "pending".
+func ringStates(keys []*key, now time.Time) []keyState {
+	states := make([]keyState, len(keys))
+
+	signingIdx := -1
+	for i, k := range keys {
+		if !now.Before(k.NotBefore) {
+			signingIdx = i
+		}
+	}
+
+	for i, k := range keys {
+		switch {
+		case now.Before(k.NotBefore):
+			states[i] = keyStatePending
+		case i == signingIdx:
+			states[i] = keyStateSigning
+		default:
+			states[i] = keyStateRetired
+		}
+	}
+
+	return states
+}
+
+// rotateRing loads the ring, advances it if a rotation or algorithm change
+// is due, prunes keys that have fallen outside the overlap window, and
+// persists whatever changed.
+func (b *backend) rotateRing(ctx context.Context, s logical.Storage) (*keyRing, error) {
+	b.keyLock.RLock()
+	if b.ringCache != nil && time.Since(b.ringCacheAt) < ringCacheTTL {
+		ring := b.ringCache
+		b.keyLock.RUnlock()
+		return ring, nil
+	}
+	b.keyLock.RUnlock()
+
+	b.keyLock.Lock()
+	defer b.keyLock.Unlock()
+
+	if b.ringCache != nil && time.Since(b.ringCacheAt) < ringCacheTTL {
+		return b.ringCache, nil
+	}
+
+	ring, err := b.loadRing(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	b.configLock.RLock()
+	alg := b.config.SigningAlgorithm
+	rotationPeriod := b.config.KeyRotationPeriod
+	prepublish := b.config.PrepublishDuration
+	skew := b.config.ClockSkewLeeway
+	overlap := b.config.KeyOverlapCount
+	b.configLock.RUnlock()
+
+	now := time.Now()
+	changed := false
+
+	if len(ring.Keys) == 0 {
+		k, err := generateKey(alg)
+		if err != nil {
+			return nil, err
+		}
+		k.NotBefore = now
+		ring.Keys = append(ring.Keys, k)
+		changed = true
+	}
+
+	latest := ring.Keys[len(ring.Keys)-1]
+	algChanged := latest.Algorithm != alg
+	rotationDue := now.Sub(latest.NotBefore) >= rotationPeriod-prepublish
+
+	switch {
+	case algChanged:
+		// The prior key can't keep signing under the new algorithm, so the
+		// replacement takes over immediately instead of waiting for
+		// prepublish. If a key was already scheduled to take over next
+		// (still pending), it's replaced in place rather than appended
+		// alongside it, since it hasn't started signing and would otherwise
+		// hand signing back to the stale algorithm once it activated.
+		next, err := generateKey(alg)
+		if err != nil {
+			return nil, err
+		}
+		next.NotBefore = now
+		if hasPendingKey(ring.Keys, now) {
+			ring.Keys[len(ring.Keys)-1] = next
+		} else {
+			ring.Keys = append(ring.Keys, next)
+		}
+		changed = true
+	case rotationDue && !hasPendingKey(ring.Keys, now):
+		next, err := generateKey(alg)
+		if err != nil {
+			return nil, err
+		}
+		next.NotBefore = latest.NotBefore.Add(rotationPeriod)
+		ring.Keys = append(ring.Keys, next)
+		changed = true
+	}
+
+	retention, err := b.retentionWindow(ctx, s, rotationPeriod, skew)
+	if err != nil {
+		return nil, err
+	}
+
+	pruned, prunedChanged := pruneRetiredKeys(ring.Keys, now, overlap, retention)
+	ring.Keys = pruned
+	changed = changed || prunedChanged
+
+	if changed {
+		if err := b.storeRing(ctx, s, ring); err != nil {
+			return nil, err
+		}
+	}
+
+	b.ringCache = ring
+	b.ringCacheAt = now
+
+	return ring, nil
+}
+
+func hasPendingKey(keys []*key, now time.Time) bool {
+	for _, k := range keys {
+		if now.Before(k.NotBefore) {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneRetiredKeys drops retired keys beyond key_overlap_count, and any
+// retired key whose retention window (key_ttl + max role jwt_ttl +
+// clock_skew_leeway since it started signing) has elapsed.
+func pruneRetiredKeys(keys []*key, now time.Time, overlap int, retention time.Duration) ([]*key, bool) {
+	states := ringStates(keys, now)
+
+	kept := make([]*key, 0, len(keys))
+	retiredSeen := 0
+	changed := false
+
+	for i := len(keys) - 1; i >= 0; i-- {
+		k := keys[i]
+		if states[i] != keyStateRetired {
+			kept = append(kept, k)
+			continue
+		}
+
+		retiredSeen++
+		if retiredSeen > overlap || now.After(k.NotBefore.Add(retention)) {
+			changed = true
+			continue
+		}
+		kept = append(kept, k)
+	}
+
+	for i, j := 0, len(kept)-1; i < j; i, j = i+1, j-1 {
+		kept[i], kept[j] = kept[j], kept[i]
+	}
+
+	return kept, changed
+}
+
+func (b *backend) loadRing(ctx context.Context, s logical.Storage) (*keyRing, error) {
+	entry, err := s.Get(ctx, keyRingStorageKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ring := &keyRing{}
+	if entry == nil {
+		return b.migrateLegacyKey(ctx, s)
+	}
+	if err := entry.DecodeJSON(ring); err != nil {
+		return nil, err
+	}
+
+	for _, k := range ring.Keys {
+		if err := k.hydrate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return ring, nil
+}
+
+// migrateLegacyKey seeds a brand new ring from the single signing key kept
+// by pre-ring versions of this backend, if one exists, so upgrading doesn't
+// orphan whatever key already-issued tokens were signed with. The legacy
+// entry is left in place; rotateRing's own persistence takes over from here.
+func (b *backend) migrateLegacyKey(ctx context.Context, s logical.Storage) (*keyRing, error) {
+	entry, err := s.Get(ctx, legacyCurrentKeyStorageKey)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return &keyRing{}, nil
+	}
+
+	k := &key{}
+	if err := entry.DecodeJSON(k); err != nil {
+		return nil, err
+	}
+	if err := k.hydrate(); err != nil {
+		return nil, err
+	}
+	k.NotBefore = k.CreatedAt
+
+	return &keyRing{Keys: []*key{k}}, nil
+}
+
+func (b *backend) storeRing(ctx context.Context, s logical.Storage, ring *keyRing) error {
+	entry, err := logical.StorageEntryJSON(keyRingStorageKey, ring)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+// retentionWindow is how long a key stays published for verification,
+// counted from when it starts signing: key_ttl for its signing period, plus
+// the longest-lived token any role could have signed with it, plus
+// clock_skew_leeway.
+func (b *backend) retentionWindow(ctx context.Context, s logical.Storage, rotationPeriod, skew time.Duration) (time.Duration, error) {
+	maxTokenTTL, err := b.maxRoleTokenTTL(ctx, s)
+	if err != nil {
+		return 0, err
+	}
+	return rotationPeriod + maxTokenTTL + skew, nil
+}
+
+// maxRoleTokenTTL returns the longest jwt_ttl configured across all roles,
+// used to size how long a retired key must stay published for verification.
+func (b *backend) maxRoleTokenTTL(ctx context.Context, s logical.Storage) (time.Duration, error) {
+	names, err := s.List(ctx, rolePrefix)
+	if err != nil {
+		return 0, err
+	}
+
+	var max time.Duration
+	for _, name := range names {
+		r, err := b.role(ctx, s, name)
+		if err != nil {
+			return 0, err
+		}
+		if r != nil && r.TokenTTL > max {
+			max = r.TokenTTL
+		}
+	}
+
+	return max, nil
+}
+
+// jwks renders every currently-valid verification key as an RFC 7517 JSON
+// Web Key.
+func (b *backend) jwks(ctx context.Context, s logical.Storage) ([]map[string]interface{}, error) {
+	keys, err := b.verificationKeys(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	jwks := make([]map[string]interface{}, 0, len(keys))
+	for _, k := range keys {
+		jwk, err := jwkForKey(k)
+		if err != nil {
+			return nil, err
+		}
+		jwks = append(jwks, jwk)
+	}
+
+	return jwks, nil
+}
+
+// keyScheduleEntry summarizes one key's place in the rotation schedule for
+// pathConfigRead, so operators can audit upcoming rotations. NotAfter is only
+// set for retired keys: it's when they'll be pruned from JWKS, derived from
+// the retention window rather than stored, since that window moves with
+// config and role changes.
+type keyScheduleEntry struct {
+	ID        string     `json:"id"`
+	Algorithm string     `json:"algorithm"`
+	State     string     `json:"state"`
+	NotBefore time.Time  `json:"not_before"`
+	NotAfter  *time.Time `json:"not_after,omitempty"`
+}
+
+func (b *backend) keySchedule(ctx context.Context, s logical.Storage) ([]keyScheduleEntry, error) {
+	ring, err := b.rotateRing(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	b.configLock.RLock()
+	rotationPeriod := b.config.KeyRotationPeriod
+	skew := b.config.ClockSkewLeeway
+	b.configLock.RUnlock()
+
+	retention, err := b.retentionWindow(ctx, s, rotationPeriod, skew)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	states := ringStates(ring.Keys, now)
+
+	schedule := make([]keyScheduleEntry, len(ring.Keys))
+	for i, k := range ring.Keys {
+		entry := keyScheduleEntry{
+			ID:        k.ID,
+			Algorithm: string(k.Algorithm),
+			State:     string(states[i]),
+			NotBefore: k.NotBefore,
+		}
+		if states[i] == keyStateRetired {
+			notAfter := k.NotBefore.Add(retention)
+			entry.NotAfter = &notAfter
+		}
+		schedule[i] = entry
+	}
+
+	return schedule, nil
+}
@@ -0,0 +1,175 @@
+package jwtsecrets
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func mustKey(t *testing.T, alg signingAlgorithm) *key {
+	t.Helper()
+	k, err := generateKey(alg)
+	if err != nil {
+		t.Fatalf("generateKey(%s): %v", alg, err)
+	}
+	return k
+}
+
+// setConfig mutates the backend's config directly, bypassing pathConfigWrite
+// so it doesn't itself trigger (and cache) a rotation before the test has
+// finished seeding the ring.
+func setConfig(b *backend, mutate func(*config)) {
+	b.configLock.Lock()
+	defer b.configLock.Unlock()
+	mutate(b.config)
+}
+
+func TestRingStates(t *testing.T) {
+	now := time.Now()
+
+	k1 := mustKey(t, algRS256)
+	k1.NotBefore = now.Add(-2 * time.Hour)
+	k2 := mustKey(t, algRS256)
+	k2.NotBefore = now.Add(-1 * time.Hour)
+	k3 := mustKey(t, algRS256)
+	k3.NotBefore = now.Add(1 * time.Hour)
+
+	states := ringStates([]*key{k1, k2, k3}, now)
+	want := []keyState{keyStateRetired, keyStateSigning, keyStatePending}
+	for i, s := range states {
+		if s != want[i] {
+			t.Fatalf("state[%d] = %s, want %s", i, s, want[i])
+		}
+	}
+}
+
+func TestPruneRetiredKeys_RespectsOverlapCount(t *testing.T) {
+	now := time.Now()
+
+	k1 := mustKey(t, algRS256) // retired, oldest
+	k1.NotBefore = now.Add(-3 * time.Hour)
+	k2 := mustKey(t, algRS256) // retired
+	k2.NotBefore = now.Add(-2 * time.Hour)
+	k3 := mustKey(t, algRS256) // signing
+	k3.NotBefore = now.Add(-1 * time.Hour)
+
+	kept, changed := pruneRetiredKeys([]*key{k1, k2, k3}, now, 1, 24*time.Hour)
+	if !changed {
+		t.Fatal("expected pruning beyond overlap to report a change")
+	}
+	if len(kept) != 2 {
+		t.Fatalf("expected 1 retired key kept plus the signing key, got %d: %#v", len(kept), kept)
+	}
+	ids := map[string]bool{}
+	for _, k := range kept {
+		ids[k.ID] = true
+	}
+	if !ids[k2.ID] || !ids[k3.ID] {
+		t.Fatalf("expected the most recently retired key and the signing key to survive, got %#v", kept)
+	}
+}
+
+func TestPruneRetiredKeys_RespectsRetentionWindow(t *testing.T) {
+	now := time.Now()
+
+	k1 := mustKey(t, algRS256) // retired, past its retention window
+	k1.NotBefore = now.Add(-48 * time.Hour)
+	k2 := mustKey(t, algRS256) // signing
+	k2.NotBefore = now.Add(-1 * time.Hour)
+
+	kept, changed := pruneRetiredKeys([]*key{k1, k2}, now, 5, 24*time.Hour)
+	if !changed {
+		t.Fatal("expected the expired retention window to report a change")
+	}
+	if len(kept) != 1 || kept[0].ID != k2.ID {
+		t.Fatalf("expected only the signing key to survive, got %#v", kept)
+	}
+}
+
+func TestRotateRing_GeneratesInitialKey(t *testing.T) {
+	b, storage := getTestBackend(t)
+	ctx := context.Background()
+
+	ring, err := b.rotateRing(ctx, storage)
+	if err != nil {
+		t.Fatalf("rotateRing: %v", err)
+	}
+	if len(ring.Keys) != 1 {
+		t.Fatalf("expected a single bootstrap key, got %d", len(ring.Keys))
+	}
+
+	states := ringStates(ring.Keys, time.Now())
+	if states[0] != keyStateSigning {
+		t.Fatalf("expected the bootstrap key to be signing immediately, got %s", states[0])
+	}
+}
+
+func TestRotateRing_PrepublishesBeforeRotationIsDue(t *testing.T) {
+	b, storage := getTestBackend(t)
+	ctx := context.Background()
+
+	setConfig(b, func(c *config) {
+		c.KeyRotationPeriod = 24 * time.Hour
+		c.PrepublishDuration = 6 * time.Hour
+	})
+
+	seed := mustKey(t, algRS256)
+	seed.NotBefore = time.Now().Add(-19 * time.Hour) // 19h into a 24h period, 6h prepublish window
+	if err := b.storeRing(ctx, storage, &keyRing{Keys: []*key{seed}}); err != nil {
+		t.Fatalf("storeRing: %v", err)
+	}
+
+	ring, err := b.rotateRing(ctx, storage)
+	if err != nil {
+		t.Fatalf("rotateRing: %v", err)
+	}
+	if len(ring.Keys) != 2 {
+		t.Fatalf("expected prepublish to add a second key, got %d: %#v", len(ring.Keys), ring.Keys)
+	}
+
+	states := ringStates(ring.Keys, time.Now())
+	if states[0] != keyStateSigning {
+		t.Fatalf("expected the seed key to still be signing, got %s", states[0])
+	}
+	if states[1] != keyStatePending {
+		t.Fatalf("expected the new key to be pending until the full rotation period elapses, got %s", states[1])
+	}
+}
+
+func TestRotateRing_AlgorithmChangeReplacesPendingKey(t *testing.T) {
+	b, storage := getTestBackend(t)
+	ctx := context.Background()
+
+	setConfig(b, func(c *config) {
+		c.SigningAlgorithm = algRS256
+	})
+
+	signing := mustKey(t, algRS256)
+	signing.NotBefore = time.Now().Add(-1 * time.Hour)
+	pending := mustKey(t, algRS256)
+	pending.NotBefore = time.Now().Add(1 * time.Hour)
+	if err := b.storeRing(ctx, storage, &keyRing{Keys: []*key{signing, pending}}); err != nil {
+		t.Fatalf("storeRing: %v", err)
+	}
+
+	setConfig(b, func(c *config) {
+		c.SigningAlgorithm = algES256
+	})
+
+	ring, err := b.rotateRing(ctx, storage)
+	if err != nil {
+		t.Fatalf("rotateRing: %v", err)
+	}
+	if len(ring.Keys) != 2 {
+		t.Fatalf("expected the stale pending key to be replaced in place, not appended to, got %d keys", len(ring.Keys))
+	}
+
+	replaced := ring.Keys[1]
+	if replaced.Algorithm != algES256 {
+		t.Fatalf("expected the replacement key to use the new algorithm, got %s", replaced.Algorithm)
+	}
+	states := ringStates(ring.Keys, time.Now())
+	if states[1] != keyStateSigning {
+		t.Fatalf("expected the new-algorithm key to take over signing immediately, got %s", states[1])
+	}
+}
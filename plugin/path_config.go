@@ -2,7 +2,7 @@ package jwtsecrets
 
 import (
 	"context"
-	"regexp"
+	"fmt"
 	"time"
 
 	"github.com/hashicorp/vault/sdk/framework"
@@ -10,16 +10,14 @@ import (
 )
 
 const (
-	keyRotationDuration    = "key_ttl"
-	keyTokenTTL            = "jwt_ttl"
-	keySetIAT              = "set_iat"
-	keySetJTI              = "set_jti"
-	keySetNBF              = "set_nbf"
-	keyIssuer              = "issuer"
-	keyAudiencePattern     = "audience_pattern"
-	keySubjectPattern      = "subject_pattern"
-	keyMaxAllowedAudiences = "max_audiences"
-	keyAllowedClaims       = "allowed_claims"
+	keyRotationDuration     = "key_ttl"
+	keyIssuerURLOverride    = "issuer_url_override"
+	keySigningAlgorithm     = "signing_algorithm"
+	keyEnableRevocation     = "enable_revocation"
+	keyRevocationTidyPeriod = "revocation_tidy_interval"
+	keyOverlapCount         = "key_overlap_count"
+	keyClockSkewLeeway      = "clock_skew_leeway"
+	keyPrepublishDuration   = "prepublish_duration"
 )
 
 func pathConfig(b *backend) *framework.Path {
@@ -30,42 +28,33 @@ func pathConfig(b *backend) *framework.Path {
 				Type:        framework.TypeString,
 				Description: `Duration before a key stops being used to sign new tokens.`,
 			},
-			keyTokenTTL: {
+			keyIssuerURLOverride: {
 				Type:        framework.TypeString,
-				Description: `Duration a token is valid for.`,
+				Description: `Externally-reachable URL to advertise as the OIDC issuer, in place of Vault's own mount path.`,
 			},
-			keySetIAT: {
-				Type:        framework.TypeBool,
-				Description: `Whether or not the backend should generate and set the 'iat' claim.`,
-			},
-			keySetJTI: {
-				Type:        framework.TypeBool,
-				Description: `Whether or not the backend should generate and set the 'jti' claim.`,
+			keySigningAlgorithm: {
+				Type:        framework.TypeString,
+				Description: `Algorithm used for the signing key: RS256, RS384, RS512, PS256, ES256, ES384, ES512 or EdDSA. Changing this generates a new key.`,
 			},
-			keySetNBF: {
+			keyEnableRevocation: {
 				Type:        framework.TypeBool,
-				Description: `Whether or not the backend should generate and set the 'nbf' claim.`,
+				Description: `Whether or not the verify path rejects tokens whose 'jti' has been revoked, and tidy runs periodically.`,
 			},
-			keyIssuer: {
+			keyRevocationTidyPeriod: {
 				Type:        framework.TypeString,
-				Description: `Value to set as the 'iss' claim. Claim is omitted if empty.`,
+				Description: `Minimum duration between automatic tidy sweeps of the revocation store.`,
 			},
-			keyAudiencePattern: {
-				Type:        framework.TypeString,
-				Description: `Regular expression which must match incoming 'aud' claims.`,
+			keyOverlapCount: {
+				Type:        framework.TypeInt,
+				Description: `How many retired keys stay published in JWKS alongside the current signing key.`,
 			},
-			keySubjectPattern: {
+			keyClockSkewLeeway: {
 				Type:        framework.TypeString,
-				Description: `Regular expression which must match incoming 'sub' claims`,
-			},
-			keyMaxAllowedAudiences: {
-				Type:        framework.TypeInt,
-				Description: `Maximum number of allowed audiences, or -1 for no limit.`,
+				Description: `Added to a retired key's publication window to account for clock drift between this backend and verifiers.`,
 			},
-			keyAllowedClaims: {
-				Type: framework.TypeStringSlice,
-				Description: `Claims which are able to be set in addition to ones generated by the backend.
-Note: 'aud' and 'sub' should be in this list if you would like to set them.`,
+			keyPrepublishDuration: {
+				Type:        framework.TypeString,
+				Description: `How long before a key becomes the signer that it's already published in JWKS, so verifier caches warm up.`,
 			},
 		},
 
@@ -84,110 +73,143 @@ Note: 'aud' and 'sub' should be in this list if you would like to set them.`,
 }
 
 func (b *backend) pathConfigWrite(c context.Context, r *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if err := b.updateConfig(d); err != nil {
+		return nil, err
+	}
+
+	// Drop the cached ring so the new settings (e.g. a changed
+	// signing_algorithm) are applied the next time the ring is consulted.
+	b.keyLock.Lock()
+	b.ringCache = nil
+	b.keyLock.Unlock()
+
+	return b.configReadResponse(c, r.Storage)
+}
+
+// updateConfig applies any fields present in d to the backend's config under
+// configLock. It's split out from pathConfigWrite so the lock is released
+// before configReadResponse goes on to read the key ring, which takes
+// configLock itself.
+func (b *backend) updateConfig(d *framework.FieldData) error {
 	b.configLock.Lock()
 	defer b.configLock.Unlock()
 
 	if newRotationPeriod, ok := d.GetOk(keyRotationDuration); ok {
 		duration, err := time.ParseDuration(newRotationPeriod.(string))
 		if err != nil {
-			return nil, err
+			return err
 		}
 		b.config.KeyRotationPeriod = duration
 	}
 
-	if newTTL, ok := d.GetOk(keyTokenTTL); ok {
-		duration, err := time.ParseDuration(newTTL.(string))
-		if err != nil {
-			return nil, err
-		}
-		b.config.TokenTTL = duration
+	if newIssuerURLOverride, ok := d.GetOk(keyIssuerURLOverride); ok {
+		b.config.IssuerURLOverride = newIssuerURLOverride.(string)
 	}
 
-	if newSetIat, ok := d.GetOk(keySetIAT); ok {
-		b.config.SetIAT = newSetIat.(bool)
+	if newAlg, ok := d.GetOk(keySigningAlgorithm); ok {
+		alg := newAlg.(string)
+		if !validSigningAlgorithm(alg) {
+			return fmt.Errorf("invalid signing_algorithm %q", alg)
+		}
+		// A key can't be reused across incompatible algorithms: the ring
+		// notices the mismatch and rotates to a compatible key the next
+		// time it's consulted, see keyring.go.
+		b.config.SigningAlgorithm = signingAlgorithm(alg)
 	}
 
-	if newSetJTI, ok := d.GetOk(keySetJTI); ok {
-		b.config.SetJTI = newSetJTI.(bool)
+	if newEnableRevocation, ok := d.GetOk(keyEnableRevocation); ok {
+		b.config.EnableRevocation = newEnableRevocation.(bool)
 	}
 
-	if newSetNBF, ok := d.GetOk(keySetNBF); ok {
-		b.config.SetNBF = newSetNBF.(bool)
+	if newTidyInterval, ok := d.GetOk(keyRevocationTidyPeriod); ok {
+		duration, err := time.ParseDuration(newTidyInterval.(string))
+		if err != nil {
+			return err
+		}
+		b.config.RevocationTidyInterval = duration
 	}
 
-	if newIssuer, ok := d.GetOk(keyIssuer); ok {
-		b.config.Issuer = newIssuer.(string)
+	if newOverlapCount, ok := d.GetOk(keyOverlapCount); ok {
+		b.config.KeyOverlapCount = newOverlapCount.(int)
 	}
 
-	if newAudiencePattern, ok := d.GetOk(keyAudiencePattern); ok {
-		pattern, err := regexp.Compile(newAudiencePattern.(string))
+	if newClockSkewLeeway, ok := d.GetOk(keyClockSkewLeeway); ok {
+		duration, err := time.ParseDuration(newClockSkewLeeway.(string))
 		if err != nil {
-			return nil, err
+			return err
 		}
-		b.config.AudiencePattern = pattern
+		b.config.ClockSkewLeeway = duration
 	}
 
-	if newSubjectPattern, ok := d.GetOk(keySubjectPattern); ok {
-		pattern, err := regexp.Compile(newSubjectPattern.(string))
+	if newPrepublishDuration, ok := d.GetOk(keyPrepublishDuration); ok {
+		duration, err := time.ParseDuration(newPrepublishDuration.(string))
 		if err != nil {
-			return nil, err
+			return err
 		}
-		b.config.SubjectPattern = pattern
-	}
-
-	if newMaxAudiences, ok := d.GetOk(keyMaxAllowedAudiences); ok {
-		b.config.MaxAudiences = newMaxAudiences.(int)
+		b.config.PrepublishDuration = duration
 	}
 
-	if newAllowedClaims, ok := d.GetOk(keyAllowedClaims); ok {
-		b.config.AllowedClaims = newAllowedClaims.([]string)
-		b.config.allowedClaimsMap = makeAllowedClaimsMap(newAllowedClaims.([]string))
-	}
+	return nil
+}
 
-	return nonLockingRead(b)
+func (b *backend) pathConfigRead(ctx context.Context, r *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return b.configReadResponse(ctx, r.Storage)
 }
 
-func (b *backend) pathConfigRead(_ context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+// configReadResponse reports the current config plus the key rotation
+// schedule. It deliberately takes configLock only after keySchedule returns,
+// since that call rotates the key ring and needs to read the config itself.
+func (b *backend) configReadResponse(ctx context.Context, s logical.Storage) (*logical.Response, error) {
+	schedule, err := b.keySchedule(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
 	b.configLock.RLock()
 	defer b.configLock.RUnlock()
 
-	return nonLockingRead(b)
-}
-
-func nonLockingRead(b *backend) (*logical.Response, error) {
 	return &logical.Response{
 		Data: map[string]interface{}{
-			keyRotationDuration:    b.config.KeyRotationPeriod.String(),
-			keyTokenTTL:            b.config.TokenTTL.String(),
-			keySetIAT:              b.config.SetIAT,
-			keySetJTI:              b.config.SetJTI,
-			keySetNBF:              b.config.SetNBF,
-			keyIssuer:              b.config.Issuer,
-			keyAudiencePattern:     b.config.AudiencePattern.String(),
-			keySubjectPattern:      b.config.SubjectPattern.String(),
-			keyMaxAllowedAudiences: b.config.MaxAudiences,
-			keyAllowedClaims:       b.config.AllowedClaims,
+			keyRotationDuration:     b.config.KeyRotationPeriod.String(),
+			keyIssuerURLOverride:    b.config.IssuerURLOverride,
+			keySigningAlgorithm:     string(b.config.SigningAlgorithm),
+			keyEnableRevocation:     b.config.EnableRevocation,
+			keyRevocationTidyPeriod: b.config.RevocationTidyInterval.String(),
+			keyOverlapCount:         b.config.KeyOverlapCount,
+			keyClockSkewLeeway:      b.config.ClockSkewLeeway.String(),
+			keyPrepublishDuration:   b.config.PrepublishDuration.String(),
+			"key_schedule":          schedule,
 		},
 	}, nil
 }
 
 const pathConfigHelpSyn = `
-Configure the backend.
+Configure the backend's key management.
 `
 
 const pathConfigHelpDesc = `
-Configure the backend.
-
-key_ttl:          Duration before a key stops signing new tokens and a new one is generated.
-		          After this period the public key will still be available to verify JWTs.
-jwt_ttl:          Duration before a token expires.
-set_iat:          Whether or not the backend should generate and set the 'iat' claim.
-set_jti:          Whether or not the backend should generate and set the 'jti' claim.
-set_nbf:          Whether or not the backend should generate and set the 'nbf' claim.
-issuer:           Value to set as the 'iss' claim. Claim omitted if empty.
-audience_pattern: Regular expression which must match incoming 'aud' claims.
-subject_pattern:  Regular expression which must match incoming 'sub' claims.
-max_audiences:    Maximum number of allowed audiences, or -1 for no limit.
-allowed_claims:   Claims which are able to be set in addition to ones generated by the backend.
-                  Note: 'aud' and 'sub' should be in this list if you would like to set them.
+Configure the backend's key management. Claim policy (issuer, audience and
+subject patterns, allowed claims, token TTL) is configured per role instead,
+see roles/<name>.
+
+key_ttl:              Duration before a key stops signing new tokens and the next one,
+                      generated prepublish_duration earlier, takes over. After this period
+                      the public key will still be available to verify JWTs.
+issuer_url_override:  Externally-reachable URL to advertise as the OIDC issuer, in place
+                      of Vault's own mount path.
+signing_algorithm:    Algorithm used for the signing key: RS256, RS384, RS512, PS256, ES256,
+                      ES384, ES512 or EdDSA. Changing this generates a new key, since a key
+                      can't be reused across incompatible algorithms.
+enable_revocation:    Whether or not the verify path rejects tokens whose 'jti' has been
+                      revoked, and tidy runs periodically.
+revocation_tidy_interval: Minimum duration between automatic tidy sweeps of the revocation
+                      store.
+key_overlap_count:    How many retired keys stay published in JWKS alongside the current
+                      signing key. A retired key is dropped once it falls outside this count
+                      or its retention window, whichever comes first.
+clock_skew_leeway:    Accounts for clock drift between this backend and verifiers: added to
+                      a retired key's retention window, and given to /verify as leeway on a
+                      token's exp/nbf claims.
+prepublish_duration:  How long before a key becomes the signer that it's already published
+                      in JWKS, so verifier caches have time to pick it up.
 `
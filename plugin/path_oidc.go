@@ -0,0 +1,104 @@
+package jwtsecrets
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathOIDCDiscovery(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `\.well-known/openid-configuration`,
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathOIDCDiscoveryRead,
+			},
+		},
+
+		HelpSynopsis:    pathOIDCDiscoveryHelpSyn,
+		HelpDescription: pathOIDCDiscoveryHelpDesc,
+	}
+}
+
+func pathJWKS(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `\.well-known/jwks\.json`,
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathJWKSRead,
+			},
+		},
+
+		HelpSynopsis:    pathJWKSHelpSyn,
+		HelpDescription: pathJWKSHelpDesc,
+	}
+}
+
+func (b *backend) pathOIDCDiscoveryRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	b.configLock.RLock()
+	issuer := b.issuer(req)
+	b.configLock.RUnlock()
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"issuer":                                issuer,
+			"jwks_uri":                              issuer + "/.well-known/jwks.json",
+			"response_types_supported":              []string{"id_token"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": supportedSigningAlgorithms(),
+			"scopes_supported":                      []string{"openid"},
+			"claims_supported":                      []string{"sub", "iss", "aud", "exp", "iat", "nbf", "jti"},
+		},
+	}, nil
+}
+
+func (b *backend) pathJWKSRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	keys, err := b.jwks(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"keys": keys,
+		},
+	}, nil
+}
+
+// issuer returns the externally-advertised issuer for this mount: the
+// operator-configured override if set, otherwise Vault's own mount path.
+//
+// req.MountPoint has no scheme or host, so without issuer_url_override the
+// returned value isn't a resolvable URL and OIDC discovery won't actually
+// work for clients; issuer_url_override is effectively mandatory for a
+// working discovery document, not just cosmetic.
+func (b *backend) issuer(req *logical.Request) string {
+	if b.config.IssuerURLOverride != "" {
+		return strings.TrimSuffix(b.config.IssuerURLOverride, "/")
+	}
+	return strings.TrimSuffix(req.MountPoint, "/")
+}
+
+const pathOIDCDiscoveryHelpSyn = `
+Returns OIDC discovery metadata for this mount.
+`
+
+const pathOIDCDiscoveryHelpDesc = `
+Unauthenticated OIDC discovery document describing the issuer and keys used
+to verify tokens signed by this backend, per the OpenID Connect Discovery
+spec.
+`
+
+const pathJWKSHelpSyn = `
+Returns the public keys used to verify tokens, as a JWKS.
+`
+
+const pathJWKSHelpDesc = `
+Unauthenticated RFC 7517 JSON Web Key Set containing every currently-valid
+public key, including retired keys that are no longer signing but may still
+be verifying previously-issued tokens.
+`
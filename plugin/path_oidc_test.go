@@ -0,0 +1,130 @@
+package jwtsecrets
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestPathOIDCDiscovery(t *testing.T) {
+	b, storage := getTestBackend(t)
+	ctx := context.Background()
+
+	resp, err := b.HandleRequest(ctx, &logical.Request{
+		Operation:  logical.ReadOperation,
+		Path:       ".well-known/openid-configuration",
+		Storage:    storage,
+		MountPoint: "jwt/",
+	})
+	if err != nil || resp == nil {
+		t.Fatalf("discovery: resp=%#v err=%v", resp, err)
+	}
+
+	issuer, _ := resp.Data["issuer"].(string)
+	if strings.HasSuffix(issuer, "/") {
+		t.Fatalf("issuer should not have a trailing slash, got %q", issuer)
+	}
+
+	jwksURI, _ := resp.Data["jwks_uri"].(string)
+	if strings.Contains(jwksURI, "//") {
+		t.Fatalf("jwks_uri should not contain a double slash, got %q", jwksURI)
+	}
+	if jwksURI != issuer+"/.well-known/jwks.json" {
+		t.Fatalf("unexpected jwks_uri: %q", jwksURI)
+	}
+
+	algs, ok := resp.Data["id_token_signing_alg_values_supported"].([]string)
+	if !ok || len(algs) != len(supportedSigningAlgorithms()) {
+		t.Fatalf("unexpected id_token_signing_alg_values_supported: %#v", resp.Data["id_token_signing_alg_values_supported"])
+	}
+}
+
+func TestPathOIDCDiscovery_IssuerURLOverride(t *testing.T) {
+	b, storage := getTestBackend(t)
+	ctx := context.Background()
+
+	_, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			keyIssuerURLOverride: "https://vault.example.com/v1/jwt/",
+		},
+	})
+	if err != nil {
+		t.Fatalf("config write: %v", err)
+	}
+
+	resp, err := b.HandleRequest(ctx, &logical.Request{
+		Operation:  logical.ReadOperation,
+		Path:       ".well-known/openid-configuration",
+		Storage:    storage,
+		MountPoint: "jwt/",
+	})
+	if err != nil || resp == nil {
+		t.Fatalf("discovery: resp=%#v err=%v", resp, err)
+	}
+
+	const want = "https://vault.example.com/v1/jwt"
+	if resp.Data["issuer"] != want {
+		t.Fatalf("expected issuer %q, got %q", want, resp.Data["issuer"])
+	}
+	if resp.Data["jwks_uri"] != want+"/.well-known/jwks.json" {
+		t.Fatalf("unexpected jwks_uri: %q", resp.Data["jwks_uri"])
+	}
+}
+
+func TestPathJWKS(t *testing.T) {
+	b, storage := getTestBackend(t)
+	ctx := context.Background()
+
+	resp, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      ".well-known/jwks.json",
+		Storage:   storage,
+	})
+	if err != nil || resp == nil {
+		t.Fatalf("jwks: resp=%#v err=%v", resp, err)
+	}
+
+	keys, ok := resp.Data["keys"].([]map[string]interface{})
+	if !ok || len(keys) != 1 {
+		t.Fatalf("expected exactly one published key, got %#v", resp.Data["keys"])
+	}
+
+	jwk := keys[0]
+	if jwk["kty"] != "RSA" {
+		t.Fatalf("expected default algorithm's key to render as an RSA JWK, got %#v", jwk)
+	}
+	for _, field := range []string{"kid", "use", "alg", "n", "e"} {
+		if _, ok := jwk[field]; !ok {
+			t.Fatalf("jwk missing field %q: %#v", field, jwk)
+		}
+	}
+}
+
+func TestPathConfig_UnauthenticatedPaths(t *testing.T) {
+	b, _ := getTestBackend(t)
+
+	special := b.Backend.PathsSpecial
+	if special == nil {
+		t.Fatal("expected PathsSpecial to be set")
+	}
+
+	want := map[string]bool{
+		`\.well-known/openid-configuration`: false,
+		`\.well-known/jwks\.json`:           false,
+	}
+	for _, p := range special.Unauthenticated {
+		if _, ok := want[p]; ok {
+			want[p] = true
+		}
+	}
+	for p, found := range want {
+		if !found {
+			t.Fatalf("expected %q to be registered as unauthenticated", p)
+		}
+	}
+}
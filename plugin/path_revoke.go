@@ -0,0 +1,121 @@
+package jwtsecrets
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const revokedPrefix = "revoked/"
+
+// jtiPattern mirrors framework.GenericNameRegex, the character class the
+// revoke/<jti> path already restricts jti to. isRevoked has to re-check it
+// because its jti comes from a token's claims, not that URL segment, and a
+// role can be configured to let callers set an arbitrary 'jti' claim.
+var jtiPattern = regexp.MustCompile(`^\w([\w.-]*\w)?$`)
+
+const (
+	keyJTI       = "jti"
+	keyExpiresAt = "exp"
+)
+
+// revokedEntry records that a token's 'jti' has been revoked, along with its
+// original expiry so tidy can reclaim the entry once the token would have
+// expired on its own anyway.
+type revokedEntry struct {
+	JTI       string    `json:"jti"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func pathRevoke(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "revoke/" + framework.GenericNameRegex(keyJTI),
+		Fields: map[string]*framework.FieldSchema{
+			keyJTI: {
+				Type:        framework.TypeString,
+				Description: `The 'jti' claim of the token to revoke.`,
+			},
+			keyExpiresAt: {
+				Type:        framework.TypeInt64,
+				Description: `Unix time the token's 'exp' claim was set to, so tidy can remove this entry once it's no longer needed.`,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathRevokeWrite,
+			},
+		},
+
+		HelpSynopsis:    pathRevokeHelpSyn,
+		HelpDescription: pathRevokeHelpDesc,
+	}
+}
+
+func pathRevokedList(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "revoked/?$",
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ListOperation: &framework.PathOperation{
+				Callback: b.pathRevokedList,
+			},
+		},
+
+		HelpSynopsis:    "List revoked token IDs.",
+		HelpDescription: "Returns the 'jti' of every token currently revoked.",
+	}
+}
+
+func (b *backend) pathRevokeWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	jti := d.Get(keyJTI).(string)
+
+	entry := &revokedEntry{JTI: jti}
+	if expUnix, ok := d.GetOk(keyExpiresAt); ok {
+		entry.ExpiresAt = time.Unix(expUnix.(int64), 0)
+	}
+
+	storageEntry, err := logical.StorageEntryJSON(revokedPrefix+jti, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, req.Storage.Put(ctx, storageEntry)
+}
+
+func (b *backend) pathRevokedList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	jtis, err := req.Storage.List(ctx, revokedPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(jtis), nil
+}
+
+func (b *backend) isRevoked(ctx context.Context, s logical.Storage, jti string) (bool, error) {
+	if jti == "" || !jtiPattern.MatchString(jti) {
+		return false, nil
+	}
+
+	entry, err := s.Get(ctx, revokedPrefix+jti)
+	if err != nil {
+		return false, err
+	}
+
+	return entry != nil, nil
+}
+
+const pathRevokeHelpSyn = `
+Revoke a token by its 'jti' claim.
+`
+
+const pathRevokeHelpDesc = `
+Records a token's 'jti' as revoked. When enable_revocation is set, tokens
+signed with set_jti no longer verify as valid once their 'jti' appears here.
+Pass the token's original
+'exp' claim as exp so the tidy endpoint can reclaim this entry once the
+token would have expired anyway.
+`
@@ -0,0 +1,242 @@
+package jwtsecrets
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestRevokeThenVerifyRejects(t *testing.T) {
+	b, storage := getTestBackend(t)
+	ctx := context.Background()
+
+	_, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			keyEnableRevocation: true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("config write: %v", err)
+	}
+
+	createRole(t, ctx, b, storage, "test", map[string]interface{}{
+		keySetJTI: true,
+	})
+
+	signResp, err := signWith(ctx, b, storage, "test", nil)
+	if err != nil || signResp == nil || signResp.IsError() {
+		t.Fatalf("sign: resp=%#v err=%v", signResp, err)
+	}
+	token := signResp.Data["token"].(string)
+
+	verifyResp, err := verifyToken(ctx, b, storage, token)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if valid, _ := verifyResp.Data["valid"].(bool); !valid {
+		t.Fatalf("expected freshly signed token to verify, got %#v", verifyResp.Data)
+	}
+
+	claims, _ := verifyResp.Data["claims"].(map[string]interface{})
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		t.Fatalf("expected token to carry a jti claim, got claims %#v", claims)
+	}
+
+	_, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "revoke/" + jti,
+		Storage:   storage,
+	})
+	if err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+
+	verifyResp, err = verifyToken(ctx, b, storage, token)
+	if err != nil {
+		t.Fatalf("verify after revoke: %v", err)
+	}
+	if valid, _ := verifyResp.Data["valid"].(bool); valid {
+		t.Fatalf("expected revoked token to fail verification, got %#v", verifyResp.Data)
+	}
+
+	listResp, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ListOperation,
+		Path:      "revoked/",
+		Storage:   storage,
+	})
+	if err != nil || listResp == nil {
+		t.Fatalf("list revoked: resp=%#v err=%v", listResp, err)
+	}
+	keys, _ := listResp.Data["keys"].([]string)
+	if len(keys) != 1 || keys[0] != jti {
+		t.Fatalf("unexpected revoked list: %#v", listResp.Data["keys"])
+	}
+}
+
+func TestVerify_RejectsExpiredToken(t *testing.T) {
+	b, storage := getTestBackend(t)
+	ctx := context.Background()
+
+	_, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			keyClockSkewLeeway: "0s",
+		},
+	})
+	if err != nil {
+		t.Fatalf("config write: %v", err)
+	}
+
+	createRole(t, ctx, b, storage, "test", map[string]interface{}{
+		keyTokenTTL: "1s",
+	})
+
+	signResp, err := signWith(ctx, b, storage, "test", nil)
+	if err != nil || signResp == nil || signResp.IsError() {
+		t.Fatalf("sign: resp=%#v err=%v", signResp, err)
+	}
+	token := signResp.Data["token"].(string)
+
+	verifyResp, err := verifyToken(ctx, b, storage, token)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if valid, _ := verifyResp.Data["valid"].(bool); !valid {
+		t.Fatalf("expected freshly signed token to verify before its ttl elapses, got %#v", verifyResp.Data)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	verifyResp, err = verifyToken(ctx, b, storage, token)
+	if err != nil {
+		t.Fatalf("verify after expiry: %v", err)
+	}
+	if valid, _ := verifyResp.Data["valid"].(bool); valid {
+		t.Fatalf("expected expired token to fail verification, got %#v", verifyResp.Data)
+	}
+}
+
+func TestIsRevoked_RejectsUnsafeJTI(t *testing.T) {
+	b, storage := getTestBackend(t)
+	ctx := context.Background()
+
+	revoked, err := b.isRevoked(ctx, storage, "../config")
+	if err != nil {
+		t.Fatalf("isRevoked: %v", err)
+	}
+	if revoked {
+		t.Fatal("expected a jti outside the safe character set to never be treated as revoked")
+	}
+}
+
+func TestTidyRevokedEntries(t *testing.T) {
+	b, storage := getTestBackend(t)
+	ctx := context.Background()
+
+	past := time.Now().Add(-1 * time.Hour).Unix()
+	future := time.Now().Add(1 * time.Hour).Unix()
+
+	for jti, exp := range map[string]int64{"expired": past, "still-valid": future} {
+		_, err := b.HandleRequest(ctx, &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "revoke/" + jti,
+			Storage:   storage,
+			Data: map[string]interface{}{
+				keyExpiresAt: exp,
+			},
+		})
+		if err != nil {
+			t.Fatalf("revoke %q: %v", jti, err)
+		}
+	}
+
+	resp, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "tidy",
+		Storage:   storage,
+	})
+	if err != nil || resp == nil {
+		t.Fatalf("tidy: resp=%#v err=%v", resp, err)
+	}
+	if removed, _ := resp.Data["removed"].(int); removed != 1 {
+		t.Fatalf("expected tidy to remove exactly 1 entry, got %#v", resp.Data["removed"])
+	}
+
+	listResp, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ListOperation,
+		Path:      "revoked/",
+		Storage:   storage,
+	})
+	if err != nil || listResp == nil {
+		t.Fatalf("list revoked: resp=%#v err=%v", listResp, err)
+	}
+	keys, _ := listResp.Data["keys"].([]string)
+	if len(keys) != 1 || keys[0] != "still-valid" {
+		t.Fatalf("expected only the unexpired entry to remain, got %#v", keys)
+	}
+}
+
+func TestPeriodicFunc_TidiesWhenRevocationEnabled(t *testing.T) {
+	b, storage := getTestBackend(t)
+	ctx := context.Background()
+
+	_, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			keyEnableRevocation:     true,
+			keyRevocationTidyPeriod: "1ns",
+		},
+	})
+	if err != nil {
+		t.Fatalf("config write: %v", err)
+	}
+
+	_, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "revoke/expired",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			keyExpiresAt: time.Now().Add(-1 * time.Hour).Unix(),
+		},
+	})
+	if err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+
+	if err := b.periodicFunc(ctx, &logical.Request{Storage: storage}); err != nil {
+		t.Fatalf("periodicFunc: %v", err)
+	}
+
+	listResp, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ListOperation,
+		Path:      "revoked/",
+		Storage:   storage,
+	})
+	if err != nil || listResp == nil {
+		t.Fatalf("list revoked: resp=%#v err=%v", listResp, err)
+	}
+	if keys, _ := listResp.Data["keys"].([]string); len(keys) != 0 {
+		t.Fatalf("expected periodicFunc to tidy the expired entry, got %#v", keys)
+	}
+}
+
+func verifyToken(ctx context.Context, b *backend, storage logical.Storage, token string) (*logical.Response, error) {
+	return b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "verify",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			keyToken: token,
+		},
+	})
+}
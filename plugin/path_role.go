@@ -0,0 +1,348 @@
+package jwtsecrets
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const rolePrefix = "role/"
+
+const (
+	keyTokenTTL            = "jwt_ttl"
+	keySetIAT              = "set_iat"
+	keySetJTI              = "set_jti"
+	keySetNBF              = "set_nbf"
+	keyIssuer              = "issuer"
+	keyAudiencePattern     = "audience_pattern"
+	keySubjectPattern      = "subject_pattern"
+	keyMaxAllowedAudiences = "max_audiences"
+	keyAllowedClaims       = "allowed_claims"
+)
+
+// role holds the claim policy enforced when signing tokens through
+// sign/<role>.
+type role struct {
+	TokenTTL           time.Duration  `json:"jwt_ttl"`
+	SetIAT             bool           `json:"set_iat"`
+	SetJTI             bool           `json:"set_jti"`
+	SetNBF             bool           `json:"set_nbf"`
+	Issuer             string         `json:"issuer"`
+	AudiencePattern    *regexp.Regexp `json:"-"`
+	AudiencePatternRaw string         `json:"audience_pattern"`
+	SubjectPattern     *regexp.Regexp `json:"-"`
+	SubjectPatternRaw  string         `json:"subject_pattern"`
+	MaxAudiences       int            `json:"max_audiences"`
+	AllowedClaims      []string       `json:"allowed_claims"`
+	allowedClaimsMap   map[string]bool
+
+	// SigningAlgorithm, if set, pins this role to a specific algorithm: sign
+	// requests fail if it no longer matches the backend's configured
+	// signing_algorithm, instead of silently signing with whatever key is
+	// current.
+	SigningAlgorithm signingAlgorithm `json:"signing_algorithm"`
+}
+
+func defaultRole() *role {
+	return &role{
+		MaxAudiences: -1,
+	}
+}
+
+func makeAllowedClaimsMap(claims []string) map[string]bool {
+	m := make(map[string]bool, len(claims))
+	for _, claim := range claims {
+		m[claim] = true
+	}
+	return m
+}
+
+func pathRole(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: `Name of the role.`,
+			},
+			keyTokenTTL: {
+				Type:        framework.TypeString,
+				Description: `Duration a token signed under this role is valid for.`,
+			},
+			keySetIAT: {
+				Type:        framework.TypeBool,
+				Description: `Whether or not the backend should generate and set the 'iat' claim.`,
+			},
+			keySetJTI: {
+				Type:        framework.TypeBool,
+				Description: `Whether or not the backend should generate and set the 'jti' claim.`,
+			},
+			keySetNBF: {
+				Type:        framework.TypeBool,
+				Description: `Whether or not the backend should generate and set the 'nbf' claim.`,
+			},
+			keyIssuer: {
+				Type:        framework.TypeString,
+				Description: `Value to set as the 'iss' claim. Claim is omitted if empty.`,
+			},
+			keyAudiencePattern: {
+				Type:        framework.TypeString,
+				Description: `Regular expression which must match incoming 'aud' claims.`,
+			},
+			keySubjectPattern: {
+				Type:        framework.TypeString,
+				Description: `Regular expression which must match incoming 'sub' claims`,
+			},
+			keyMaxAllowedAudiences: {
+				Type:        framework.TypeInt,
+				Description: `Maximum number of allowed audiences, or -1 for no limit.`,
+			},
+			keyAllowedClaims: {
+				Type: framework.TypeStringSlice,
+				Description: `Claims which are able to be set in addition to ones generated by the backend.
+Note: 'aud' and 'sub' should be in this list if you would like to set them.`,
+			},
+			keySigningAlgorithm: {
+				Type:        framework.TypeString,
+				Description: `If set, pins this role to an algorithm; sign requests fail if it no longer matches the backend's configured signing_algorithm.`,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.CreateOperation: &framework.PathOperation{
+				Callback: b.pathRoleWrite,
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathRoleWrite,
+			},
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathRoleRead,
+			},
+			logical.DeleteOperation: &framework.PathOperation{
+				Callback: b.pathRoleDelete,
+			},
+		},
+
+		ExistenceCheck: b.pathRoleExistenceCheck,
+
+		HelpSynopsis:    pathRoleHelpSyn,
+		HelpDescription: pathRoleHelpDesc,
+	}
+}
+
+// pathRoleExistenceCheck distinguishes create from update for the framework;
+// pathRoleWrite itself handles both identically.
+func (b *backend) pathRoleExistenceCheck(ctx context.Context, req *logical.Request, d *framework.FieldData) (bool, error) {
+	r, err := b.role(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return r != nil, nil
+}
+
+func pathRoleList(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/?$",
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ListOperation: &framework.PathOperation{
+				Callback: b.pathRoleList,
+			},
+		},
+
+		HelpSynopsis:    pathRoleHelpSyn,
+		HelpDescription: pathRoleHelpDesc,
+	}
+}
+
+func (b *backend) role(ctx context.Context, s logical.Storage, name string) (*role, error) {
+	entry, err := s.Get(ctx, rolePrefix+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	r := &role{}
+	if err := entry.DecodeJSON(r); err != nil {
+		return nil, err
+	}
+
+	if r.AudiencePatternRaw != "" {
+		r.AudiencePattern, err = regexp.Compile(r.AudiencePatternRaw)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if r.SubjectPatternRaw != "" {
+		r.SubjectPattern, err = regexp.Compile(r.SubjectPatternRaw)
+		if err != nil {
+			return nil, err
+		}
+	}
+	r.allowedClaimsMap = makeAllowedClaimsMap(r.AllowedClaims)
+
+	return r, nil
+}
+
+func (b *backend) pathRoleWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	r, err := b.role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		r = defaultRole()
+	}
+
+	if newTTL, ok := d.GetOk(keyTokenTTL); ok {
+		duration, err := time.ParseDuration(newTTL.(string))
+		if err != nil {
+			return nil, err
+		}
+		r.TokenTTL = duration
+	}
+
+	if newSetIat, ok := d.GetOk(keySetIAT); ok {
+		r.SetIAT = newSetIat.(bool)
+	}
+
+	if newSetJTI, ok := d.GetOk(keySetJTI); ok {
+		r.SetJTI = newSetJTI.(bool)
+	}
+
+	if newSetNBF, ok := d.GetOk(keySetNBF); ok {
+		r.SetNBF = newSetNBF.(bool)
+	}
+
+	if newIssuer, ok := d.GetOk(keyIssuer); ok {
+		r.Issuer = newIssuer.(string)
+	}
+
+	if newAudiencePattern, ok := d.GetOk(keyAudiencePattern); ok {
+		pattern, err := regexp.Compile(newAudiencePattern.(string))
+		if err != nil {
+			return nil, err
+		}
+		r.AudiencePattern = pattern
+		r.AudiencePatternRaw = newAudiencePattern.(string)
+	}
+
+	if newSubjectPattern, ok := d.GetOk(keySubjectPattern); ok {
+		pattern, err := regexp.Compile(newSubjectPattern.(string))
+		if err != nil {
+			return nil, err
+		}
+		r.SubjectPattern = pattern
+		r.SubjectPatternRaw = newSubjectPattern.(string)
+	}
+
+	if newMaxAudiences, ok := d.GetOk(keyMaxAllowedAudiences); ok {
+		r.MaxAudiences = newMaxAudiences.(int)
+	}
+
+	if newAllowedClaims, ok := d.GetOk(keyAllowedClaims); ok {
+		r.AllowedClaims = newAllowedClaims.([]string)
+		r.allowedClaimsMap = makeAllowedClaimsMap(r.AllowedClaims)
+	}
+
+	if newAlg, ok := d.GetOk(keySigningAlgorithm); ok {
+		alg := newAlg.(string)
+		if alg != "" && !validSigningAlgorithm(alg) {
+			return nil, fmt.Errorf("invalid signing_algorithm %q", alg)
+		}
+		r.SigningAlgorithm = signingAlgorithm(alg)
+	}
+
+	entry, err := logical.StorageEntryJSON(rolePrefix+name, r)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return roleReadResponse(r), nil
+}
+
+func (b *backend) pathRoleRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	r, err := b.role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, nil
+	}
+
+	return roleReadResponse(r), nil
+}
+
+func (b *backend) pathRoleDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	if err := req.Storage.Delete(ctx, rolePrefix+name); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathRoleList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	names, err := req.Storage.List(ctx, rolePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(names), nil
+}
+
+func roleReadResponse(r *role) *logical.Response {
+	data := map[string]interface{}{
+		keyTokenTTL:            r.TokenTTL.String(),
+		keySetIAT:              r.SetIAT,
+		keySetJTI:              r.SetJTI,
+		keySetNBF:              r.SetNBF,
+		keyIssuer:              r.Issuer,
+		keyMaxAllowedAudiences: r.MaxAudiences,
+		keyAllowedClaims:       r.AllowedClaims,
+		keySigningAlgorithm:    string(r.SigningAlgorithm),
+	}
+	if r.AudiencePattern != nil {
+		data[keyAudiencePattern] = r.AudiencePattern.String()
+	}
+	if r.SubjectPattern != nil {
+		data[keySubjectPattern] = r.SubjectPattern.String()
+	}
+
+	return &logical.Response{Data: data}
+}
+
+const pathRoleHelpSyn = `
+Manage the roles that can be used to sign tokens.
+`
+
+const pathRoleHelpDesc = `
+Each role stores its own claim policy, independent of every other role and
+of the backend's key-management config:
+
+jwt_ttl:          Duration a token signed under this role is valid for.
+set_iat:          Whether or not the backend should generate and set the 'iat' claim.
+set_jti:          Whether or not the backend should generate and set the 'jti' claim.
+set_nbf:          Whether or not the backend should generate and set the 'nbf' claim.
+issuer:           Value to set as the 'iss' claim. Claim omitted if empty.
+audience_pattern: Regular expression which must match incoming 'aud' claims.
+subject_pattern:  Regular expression which must match incoming 'sub' claims.
+max_audiences:    Maximum number of allowed audiences, or -1 for no limit.
+allowed_claims:   Claims which are able to be set in addition to ones generated by the backend.
+                  Note: 'aud' and 'sub' should be in this list if you would like to set them.
+signing_algorithm: If set, pins this role to an algorithm; sign requests fail once it no
+                  longer matches the backend's configured signing_algorithm.
+`
@@ -0,0 +1,171 @@
+package jwtsecrets
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestPathRole_CRUD(t *testing.T) {
+	b, storage := getTestBackend(t)
+	ctx := context.Background()
+
+	resp, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "roles/test",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			keyTokenTTL:            "1h",
+			keyMaxAllowedAudiences: 2,
+			keyAllowedClaims:       []string{"aud"},
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("create role: resp=%#v err=%v", resp, err)
+	}
+
+	resp, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "roles/test",
+		Storage:   storage,
+	})
+	if err != nil || resp == nil {
+		t.Fatalf("read role: resp=%#v err=%v", resp, err)
+	}
+	if resp.Data[keyTokenTTL] != "1h0m0s" {
+		t.Fatalf("unexpected jwt_ttl: %#v", resp.Data[keyTokenTTL])
+	}
+	if resp.Data[keyMaxAllowedAudiences] != 2 {
+		t.Fatalf("unexpected max_audiences: %#v", resp.Data[keyMaxAllowedAudiences])
+	}
+
+	resp, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ListOperation,
+		Path:      "roles/",
+		Storage:   storage,
+	})
+	if err != nil || resp == nil {
+		t.Fatalf("list roles: resp=%#v err=%v", resp, err)
+	}
+	keys, ok := resp.Data["keys"].([]string)
+	if !ok || len(keys) != 1 || keys[0] != "test" {
+		t.Fatalf("unexpected role list: %#v", resp.Data["keys"])
+	}
+
+	_, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.DeleteOperation,
+		Path:      "roles/test",
+		Storage:   storage,
+	})
+	if err != nil {
+		t.Fatalf("delete role: %v", err)
+	}
+
+	resp, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "roles/test",
+		Storage:   storage,
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("expected nil response after delete, got resp=%#v err=%v", resp, err)
+	}
+}
+
+func createRole(t *testing.T, ctx context.Context, b *backend, storage logical.Storage, name string, data map[string]interface{}) {
+	t.Helper()
+	resp, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "roles/" + name,
+		Storage:   storage,
+		Data:      data,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("create role %q: resp=%#v err=%v", name, resp, err)
+	}
+}
+
+func signWith(ctx context.Context, b *backend, storage logical.Storage, role string, claims map[string]interface{}) (*logical.Response, error) {
+	return b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "sign/" + role,
+		Storage:   storage,
+		Data: map[string]interface{}{
+			keyClaims: claims,
+		},
+	})
+}
+
+func TestPathSign_AudiencePattern(t *testing.T) {
+	b, storage := getTestBackend(t)
+	ctx := context.Background()
+
+	createRole(t, ctx, b, storage, "test", map[string]interface{}{
+		keyAudiencePattern: "^allowed-.*$",
+		keyAllowedClaims:   []string{"aud"},
+	})
+
+	if resp, err := signWith(ctx, b, storage, "test", map[string]interface{}{"aud": "allowed-service"}); err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("expected matching audience to sign, resp=%#v err=%v", resp, err)
+	}
+
+	resp, err := signWith(ctx, b, storage, "test", map[string]interface{}{"aud": "forbidden-service"})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected non-matching audience to be rejected, got %#v", resp)
+	}
+}
+
+func TestPathSign_MaxAudiencesEnforced(t *testing.T) {
+	b, storage := getTestBackend(t)
+	ctx := context.Background()
+
+	createRole(t, ctx, b, storage, "test", map[string]interface{}{
+		keyMaxAllowedAudiences: 1,
+		keyAllowedClaims:       []string{"aud"},
+	})
+
+	if resp, err := signWith(ctx, b, storage, "test", map[string]interface{}{"aud": "one"}); err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("expected single audience to sign, resp=%#v err=%v", resp, err)
+	}
+
+	resp, err := signWith(ctx, b, storage, "test", map[string]interface{}{
+		"aud": []interface{}{"one", "two", "three"},
+	})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected max_audiences to reject a 3-audience token, got %#v", resp)
+	}
+}
+
+func TestPathSign_AllowedClaims(t *testing.T) {
+	b, storage := getTestBackend(t)
+	ctx := context.Background()
+
+	createRole(t, ctx, b, storage, "test", map[string]interface{}{})
+
+	resp, err := signWith(ctx, b, storage, "test", map[string]interface{}{"sub": "not-allowed"})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected claim not in allowed_claims to be rejected, got %#v", resp)
+	}
+}
+
+func TestPathSign_UnknownRole(t *testing.T) {
+	b, storage := getTestBackend(t)
+	ctx := context.Background()
+
+	resp, err := signWith(ctx, b, storage, "does-not-exist", nil)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected error response for unknown role, got %#v", resp)
+	}
+}
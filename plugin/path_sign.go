@@ -0,0 +1,161 @@
+package jwtsecrets
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	jose "gopkg.in/square/go-jose.v2"
+	jwt "gopkg.in/square/go-jose.v2/jwt"
+)
+
+const (
+	keyClaims = "claims"
+)
+
+func pathSign(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "sign/" + framework.GenericNameRegex("role"),
+		Fields: map[string]*framework.FieldSchema{
+			"role": {
+				Type:        framework.TypeString,
+				Description: `Name of the role to sign with.`,
+			},
+			keyClaims: {
+				Type:        framework.TypeMap,
+				Description: `Claims to include in the token, in addition to those generated by the backend.`,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathSignWrite,
+			},
+		},
+
+		HelpSynopsis:    pathSignHelpSyn,
+		HelpDescription: pathSignHelpDesc,
+	}
+}
+
+func (b *backend) pathSignWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	roleName := d.Get("role").(string)
+
+	r, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return logical.ErrorResponse("unknown role %q", roleName), nil
+	}
+
+	rawClaims, _ := d.Get(keyClaims).(map[string]interface{})
+	claims := map[string]interface{}{}
+	for k, v := range rawClaims {
+		if !r.allowedClaimsMap[k] {
+			return logical.ErrorResponse("claim %q is not allowed by role %q", k, roleName), nil
+		}
+		claims[k] = v
+	}
+
+	now := time.Now()
+	if r.Issuer != "" {
+		claims["iss"] = r.Issuer
+	}
+	if r.SetIAT {
+		claims["iat"] = now.Unix()
+	}
+	if r.SetNBF {
+		claims["nbf"] = now.Unix()
+	}
+	if r.TokenTTL > 0 {
+		claims["exp"] = now.Add(r.TokenTTL).Unix()
+	}
+	if r.SetJTI {
+		id, err := generateUUID()
+		if err != nil {
+			return nil, err
+		}
+		claims["jti"] = id
+	}
+
+	if aud, ok := claims["aud"]; ok {
+		if r.AudiencePattern != nil && !audienceMatches(aud, r.AudiencePattern) {
+			return logical.ErrorResponse("'aud' claim does not match role's audience_pattern"), nil
+		}
+		if r.MaxAudiences >= 0 && audienceCount(aud) > r.MaxAudiences {
+			return logical.ErrorResponse("'aud' claim has more audiences than role %q allows (max_audiences=%d)", roleName, r.MaxAudiences), nil
+		}
+	}
+	if sub, ok := claims["sub"].(string); ok && r.SubjectPattern != nil {
+		if !r.SubjectPattern.MatchString(sub) {
+			return logical.ErrorResponse("'sub' claim does not match role's subject_pattern"), nil
+		}
+	}
+
+	signingKey, err := b.currentKey(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if r.SigningAlgorithm != "" && r.SigningAlgorithm != signingKey.Algorithm {
+		return logical.ErrorResponse("role %q is pinned to %s but the backend is currently signing with %s", roleName, r.SigningAlgorithm, signingKey.Algorithm), nil
+	}
+
+	token, err := signClaims(signingKey, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"token": token,
+		},
+	}, nil
+}
+
+func audienceCount(aud interface{}) int {
+	switch v := aud.(type) {
+	case string:
+		return 1
+	case []interface{}:
+		return len(v)
+	default:
+		return 0
+	}
+}
+
+func audienceMatches(aud interface{}, pattern interface{ MatchString(string) bool }) bool {
+	switch v := aud.(type) {
+	case string:
+		return pattern.MatchString(v)
+	case []interface{}:
+		for _, a := range v {
+			if s, ok := a.(string); ok && pattern.MatchString(s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func signClaims(k *key, claims map[string]interface{}) (string, error) {
+	signer, err := jose.NewSigner(jose.SigningKey{
+		Algorithm: jose.SignatureAlgorithm(k.Algorithm),
+		Key:       k.PrivateKey,
+	}, (&jose.SignerOptions{}).WithHeader("kid", k.ID).WithType("JWT"))
+	if err != nil {
+		return "", err
+	}
+
+	return jwt.Signed(signer).Claims(claims).CompactSerialize()
+}
+
+const pathSignHelpSyn = `
+Sign a JWT using the constraints configured on a role.
+`
+
+const pathSignHelpDesc = `
+Signs a JWT with the current signing key, enforcing the issuer, audience,
+subject and allowed-claims policy configured on roles/<role>.
+`
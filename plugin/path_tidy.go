@@ -0,0 +1,72 @@
+package jwtsecrets
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathTidy(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "tidy",
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathTidyWrite,
+			},
+		},
+
+		HelpSynopsis:    "Remove expired revocation entries.",
+		HelpDescription: "Walks the revocation store and deletes entries whose original 'exp' has passed, so the revoked list can't grow unbounded. Runs automatically on revocation_tidy_interval when enable_revocation is set.",
+	}
+}
+
+func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	removed, err := b.tidyRevokedEntries(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"removed": removed,
+		},
+	}, nil
+}
+
+// tidyRevokedEntries deletes revoked entries whose original expiry has
+// passed, returning how many were removed.
+func (b *backend) tidyRevokedEntries(ctx context.Context, s logical.Storage) (int, error) {
+	jtis, err := s.List(ctx, revokedPrefix)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	removed := 0
+	for _, jti := range jtis {
+		entry, err := s.Get(ctx, revokedPrefix+jti)
+		if err != nil {
+			return removed, err
+		}
+		if entry == nil {
+			continue
+		}
+
+		re := &revokedEntry{}
+		if err := entry.DecodeJSON(re); err != nil {
+			return removed, err
+		}
+
+		if !re.ExpiresAt.IsZero() && now.After(re.ExpiresAt) {
+			if err := s.Delete(ctx, revokedPrefix+jti); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+	}
+
+	return removed, nil
+}
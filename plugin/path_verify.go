@@ -0,0 +1,86 @@
+package jwtsecrets
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	jwt "gopkg.in/square/go-jose.v2/jwt"
+)
+
+const keyToken = "token"
+
+func pathVerify(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "verify",
+		Fields: map[string]*framework.FieldSchema{
+			keyToken: {
+				Type:        framework.TypeString,
+				Description: `Token to verify.`,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathVerifyWrite,
+			},
+		},
+
+		HelpSynopsis:    "Verify a token signed by this backend.",
+		HelpDescription: "Checks a token's signature and 'exp'/'nbf' claims against this backend's keys and clock_skew_leeway, and, when enable_revocation is set, rejects it if its 'jti' has been revoked.",
+	}
+}
+
+func (b *backend) pathVerifyWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	raw := d.Get(keyToken).(string)
+
+	token, err := jwt.ParseSigned(raw)
+	if err != nil {
+		return logical.ErrorResponse("could not parse token: %s", err), nil
+	}
+
+	keys, err := b.verificationKeys(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	var registered jwt.Claims
+	var verified bool
+	for _, k := range keys {
+		if err := token.Claims(k.PrivateKey.Public(), &claims, &registered); err == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return &logical.Response{Data: map[string]interface{}{"valid": false, "error": "signature verification failed"}}, nil
+	}
+
+	b.configLock.RLock()
+	revocationEnabled := b.config.EnableRevocation
+	leeway := b.config.ClockSkewLeeway
+	b.configLock.RUnlock()
+
+	if err := registered.ValidateWithLeeway(jwt.Expected{Time: time.Now()}, leeway); err != nil {
+		return &logical.Response{Data: map[string]interface{}{"valid": false, "error": err.Error()}}, nil
+	}
+
+	if jti, ok := claims["jti"].(string); ok && revocationEnabled {
+		revoked, err := b.isRevoked(ctx, req.Storage, jti)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return &logical.Response{Data: map[string]interface{}{"valid": false, "error": "token has been revoked"}}, nil
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"valid":  true,
+			"claims": claims,
+		},
+	}, nil
+}
@@ -0,0 +1,9 @@
+package jwtsecrets
+
+import (
+	uuid "github.com/hashicorp/go-uuid"
+)
+
+func generateUUID() (string, error) {
+	return uuid.GenerateUUID()
+}